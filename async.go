@@ -0,0 +1,186 @@
+package golog
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Message is a queued log record consumed by the async drain goroutine. The
+// format and args are kept unevaluated so enqueuing stays cheap; formatting
+// happens off the hot path when the drain goroutine dequeues it.
+type Message struct {
+	level  LogLevel
+	ts     time.Time
+	format string
+	args   []interface{}
+	fields map[string]interface{}
+
+	// flush, when non-nil, marks this Message as a Flush() sentinel rather
+	// than a real record: the drain goroutine closes it instead of dispatching.
+	flush chan struct{}
+}
+
+// DropPolicy controls what happens when an async logger's queue is full.
+type DropPolicy int
+
+const (
+	// DropPolicyBlock blocks the caller until space is available.
+	DropPolicyBlock DropPolicy = iota
+	// DropPolicyDropNewest discards the message being enqueued.
+	DropPolicyDropNewest
+	// DropPolicyDropOldest discards the oldest queued message to make room.
+	DropPolicyDropOldest
+)
+
+// Stats reports operational counters for a Logger.
+type Stats struct {
+	// Dropped is the number of messages discarded because the async queue
+	// was full. Always zero for synchronous loggers.
+	Dropped uint64
+}
+
+// asyncState holds the queue and drain goroutine backing an async Logger.
+// It is shared (by pointer) with any Logger produced via WithFields so all
+// of them enqueue onto the same drain goroutine.
+type asyncState struct {
+	queue      chan Message
+	dropPolicy atomic.Int32
+	dropped    atomic.Uint64
+	wg         sync.WaitGroup
+
+	// closeMu guards against sending on queue after it has been closed:
+	// enqueue/drainSync hold it for reading while they send, and stop takes
+	// it for writing before closing the channel, so a send can never race a
+	// close.
+	closeMu sync.RWMutex
+	closed  bool
+}
+
+// NewAsyncLogger initializes a Logger like NewLogger, but log calls enqueue
+// onto a bounded channel of size bufSize instead of writing to the sinks
+// synchronously; a dedicated goroutine drains the queue into the sinks.
+func NewAsyncLogger(logFilePath string, bufSize int) (*Logger, error) {
+	l, err := NewLogger(logFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	async := &asyncState{
+		queue: make(chan Message, bufSize),
+	}
+	async.wg.Add(1)
+	go async.drain(l)
+
+	l.mu.Lock()
+	l.async = async
+	l.mu.Unlock()
+
+	return l, nil
+}
+
+// SetDropPolicy configures how an async logger behaves when its queue is
+// full. It has no effect on synchronous loggers.
+func (l *Logger) SetDropPolicy(policy DropPolicy) {
+	l.mu.Lock()
+	async := l.async
+	l.mu.Unlock()
+
+	if async == nil {
+		return
+	}
+	async.dropPolicy.Store(int32(policy))
+}
+
+// Stats reports the logger's operational counters.
+func (l *Logger) Stats() Stats {
+	l.mu.Lock()
+	async := l.async
+	l.mu.Unlock()
+
+	if async == nil {
+		return Stats{}
+	}
+	return Stats{Dropped: async.dropped.Load()}
+}
+
+// enqueue applies the configured drop policy and pushes msg onto the queue.
+// It is a no-op once the queue has been closed by stop, so a log call racing
+// Close() never panics on a send to a closed channel.
+func (a *asyncState) enqueue(msg Message) {
+	a.closeMu.RLock()
+	defer a.closeMu.RUnlock()
+	if a.closed {
+		return
+	}
+
+	select {
+	case a.queue <- msg:
+		return
+	default:
+	}
+
+	switch DropPolicy(a.dropPolicy.Load()) {
+	case DropPolicyDropNewest:
+		a.dropped.Add(1)
+	case DropPolicyDropOldest:
+		select {
+		case <-a.queue:
+			a.dropped.Add(1)
+		default:
+		}
+		select {
+		case a.queue <- msg:
+		default:
+			a.dropped.Add(1)
+		}
+	default: // DropPolicyBlock
+		a.queue <- msg
+	}
+}
+
+// drain consumes messages until the queue is closed, formatting and
+// dispatching each one to l's sinks.
+func (a *asyncState) drain(l *Logger) {
+	defer a.wg.Done()
+	for msg := range a.queue {
+		if msg.flush != nil {
+			close(msg.flush)
+			continue
+		}
+		l.dispatch(msg.level, msg.ts, fmt.Sprintf(msg.format, msg.args...), msg.fields)
+	}
+}
+
+// drainSync blocks until every message enqueued so far has been dispatched,
+// without stopping the drain goroutine. It is a no-op once stop has closed
+// the queue.
+func (a *asyncState) drainSync() {
+	a.closeMu.RLock()
+	defer a.closeMu.RUnlock()
+	if a.closed {
+		return
+	}
+
+	done := make(chan struct{})
+	a.queue <- Message{flush: done}
+	<-done
+}
+
+// stop closes the queue and waits for the drain goroutine to finish
+// processing whatever was already enqueued. It is safe to call concurrently
+// with in-flight log calls: enqueue and drainSync hold closeMu for reading
+// while they send, so stop's write lock can't be acquired (and the channel
+// can't be closed) until any such send has completed. Calling stop more
+// than once is a no-op.
+func (a *asyncState) stop() {
+	a.closeMu.Lock()
+	defer a.closeMu.Unlock()
+	if a.closed {
+		return
+	}
+	a.closed = true
+	close(a.queue)
+	a.wg.Wait()
+}
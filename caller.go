@@ -0,0 +1,43 @@
+package golog
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// baseCallerSkip is the number of stack frames between callerFields' call to
+// runtime.Caller and the user's call to Debug/Info/Warning/Error: emit ->
+// (Debug|Info|Warning|Error) -> the user's call site.
+const baseCallerSkip = 3
+
+// callerFields returns base with "file", "line", and (when resolvable)
+// "func" fields merged in, describing the source location skip frames above
+// this function. It returns base unmodified if the frame can't be resolved.
+func callerFields(base map[string]interface{}, skip int) map[string]interface{} {
+	pc, file, line, ok := runtime.Caller(skip)
+	if !ok {
+		return base
+	}
+
+	merged := make(map[string]interface{}, len(base)+3)
+	for k, v := range base {
+		merged[k] = v
+	}
+	merged["file"] = file
+	merged["line"] = line
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		merged["func"] = fn.Name()
+	}
+	return merged
+}
+
+// stackField returns base with the calling goroutine's stack trace merged
+// in under "stack", for attachment to ERROR records.
+func stackField(base map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+1)
+	for k, v := range base {
+		merged[k] = v
+	}
+	merged["stack"] = string(debug.Stack())
+	return merged
+}
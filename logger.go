@@ -1,11 +1,9 @@
 package golog
 
 import (
-	"encoding/json"
 	"fmt"
-	"log"
-	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -20,172 +18,225 @@ const (
 	// Default values for log rotation
 	defaultMaxSize    = 10 * 1024 * 1024 // 10MB
 	defaultMaxBackups = 5
+	defaultMaxLines   = 100000
 )
 
+// sinkEntry pairs a registered LogSink with the minimum level it accepts.
+type sinkEntry struct {
+	sink     LogSink
+	minLevel LogLevel
+}
+
 type Logger struct {
-	debug   *log.Logger
-	info    *log.Logger
-	warning *log.Logger
-	error   *log.Logger
-	file    *os.File
+	mu    sync.Mutex
+	sinks []sinkEntry
 
 	// Configuration
-	minLevel    LogLevel
-	maxFileSize int64
-	maxBackups  int
-	filePath    string
-	currentSize int64
-	mu          sync.Mutex
+	minLevel LogLevel
+
+	// async holds the queue and drain goroutine state for NewAsyncLogger;
+	// nil for the default synchronous mode. Shared with WithFields clones.
+	async *asyncState
 
 	// Structured logging
 	fields map[string]interface{}
+
+	// Verbosity gating for V(level), set via SetVerbosity/SetVModule.
+	verbosity    int32
+	vmodule      []vmoduleRule
+	vmoduleCache sync.Map
+
+	// Caller annotation, set via EnableCallerInfo/EnableStackTrace.
+	callerEnabled     bool
+	callerDepth       int
+	stackTraceEnabled bool
 }
 
-// NewLogger initializes the logger and writes WARNING and ERROR logs to a file
+// NewLogger initializes the logger, composing a stdout sink for DEBUG/INFO
+// and a rotating file sink for WARNING/ERROR.
 func NewLogger(logFilePath string) (*Logger, error) {
-	file, err := os.OpenFile(logFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
+	fileSink, err := NewFileSink(FileSinkConfig{Path: logFilePath})
 	if err != nil {
-		return nil, fmt.Errorf("failed to open log file: %w", err)
+		return nil, err
+	}
+
+	l := &Logger{
+		minLevel: DEBUG,
+		fields:   make(map[string]interface{}),
 	}
+	l.AddSink(&legacyConsoleSink{inner: NewStdoutSink()}, DEBUG)
+	l.AddSink(fileSink, WARNING)
+	return l, nil
+}
 
-	return &Logger{
-		debug:       log.New(os.Stdout, "DEBUG: ", log.Ldate|log.Ltime),
-		info:        log.New(os.Stdout, "INFO: ", log.Ldate|log.Ltime),
-		warning:     log.New(file, "WARNING: ", log.Ldate|log.Ltime),
-		error:       log.New(file, "ERROR: ", log.Ldate|log.Ltime),
-		file:        file,
-		minLevel:    DEBUG,
-		maxFileSize: defaultMaxSize,
-		maxBackups:  defaultMaxBackups,
-		filePath:    logFilePath,
-		fields:      make(map[string]interface{}),
-	}, nil
+// AddSink registers a sink that receives records at or above minLevel.
+func (l *Logger) AddSink(sink LogSink, minLevel LogLevel) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sinks = append(l.sinks, sinkEntry{sink: sink, minLevel: minLevel})
 }
 
 func (l *Logger) Debug(format string, v ...interface{}) {
-	if l.minLevel <= DEBUG {
-		message := l.formatMessage("DEBUG", format, v...)
-		l.debug.Print(message)
-	}
+	l.emit(DEBUG, format, v...)
 }
 
 func (l *Logger) Info(format string, v ...interface{}) {
-	if l.minLevel <= INFO {
-		message := l.formatMessage("INFO", format, v...)
-		l.info.Print(message)
-	}
+	l.emit(INFO, format, v...)
 }
 
 func (l *Logger) Warning(format string, v ...interface{}) {
-	if l.minLevel <= WARNING {
-		message := l.formatMessage("WARNING", format, v...)
-		l.warning.Print(message)
-		l.checkRotation(len(message))
-	}
+	l.emit(WARNING, format, v...)
 }
 
 func (l *Logger) Error(format string, v ...interface{}) {
-	if l.minLevel <= ERROR {
-		message := l.formatMessage("ERROR", format, v...)
-		l.error.Print(message)
-		l.checkRotation(len(message))
+	l.emit(ERROR, format, v...)
+}
+
+// emit gates the record on the logger's minimum level, then either dispatches
+// it to the sinks directly or, in async mode, enqueues it for the drain
+// goroutine so the caller doesn't block on sink I/O.
+func (l *Logger) emit(level LogLevel, format string, v ...interface{}) {
+	l.mu.Lock()
+	minLevel := l.minLevel
+	fields := l.fields
+	async := l.async
+	callerEnabled := l.callerEnabled
+	callerDepth := l.callerDepth
+	stackTraceEnabled := l.stackTraceEnabled
+	l.mu.Unlock()
+
+	if level < minLevel {
+		return
+	}
+
+	// Caller/stack info must be captured here, synchronously on the calling
+	// goroutine, since neither the source frame nor the stack is meaningful
+	// once dispatch happens later on the async drain goroutine.
+	if callerEnabled {
+		fields = callerFields(fields, baseCallerSkip+callerDepth)
+	}
+	if level == ERROR && stackTraceEnabled {
+		fields = stackField(fields)
+	}
+
+	ts := time.Now()
+	if async != nil {
+		async.enqueue(Message{level: level, ts: ts, format: format, args: v, fields: fields})
+		return
+	}
+
+	l.dispatch(level, ts, fmt.Sprintf(format, v...), fields)
+}
+
+// dispatch fans a formatted record out to every sink whose minLevel is
+// satisfied.
+func (l *Logger) dispatch(level LogLevel, ts time.Time, msg string, fields map[string]interface{}) {
+	l.mu.Lock()
+	sinks := l.sinks
+	l.mu.Unlock()
+
+	for _, entry := range sinks {
+		if level < entry.minLevel {
+			continue
+		}
+		entry.sink.Emit(level, ts, msg, fields)
 	}
 }
 
 // SetLevel sets the minimum log level
 func (l *Logger) SetLevel(level LogLevel) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	l.minLevel = level
 }
 
-// WithFields adds structured fields to the log output
-func (l *Logger) WithFields(fields map[string]interface{}) *Logger {
-newLogger := Logger{
-    debug:       l.debug,
-    info:        l.info,
-    warning:     l.warning,
-    error:       l.error,
-    file:        l.file,
-    minLevel:    l.minLevel,
-    maxFileSize: l.maxFileSize,
-    maxBackups:  l.maxBackups,
-    filePath:    l.filePath,
-    currentSize: l.currentSize,
-    fields:      make(map[string]interface{}),
-}
-	newLogger.fields = make(map[string]interface{}, len(l.fields)+len(fields))
-	for k, v := range l.fields {
-		newLogger.fields[k] = v
-	}
-	for k, v := range fields {
-		newLogger.fields[k] = v
-	}
-	return &newLogger
+// EnableCallerInfo turns on "file", "line", and "func" fields on every
+// emitted record, analogous to beego's EnableFuncCallDepth. depth is the
+// number of additional stack frames to skip beyond a direct Debug/Info/
+// Warning/Error call, for callers that wrap this Logger in their own
+// helper functions; pass 0 when calling the logging methods directly.
+func (l *Logger) EnableCallerInfo(depth int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.callerEnabled = true
+	l.callerDepth = depth
 }
 
-// formatMessage formats the log message with timestamp and structured fields
-func (l *Logger) formatMessage(level string, format string, v ...interface{}) string {
-	message := fmt.Sprintf(format, v...)
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-
-	if len(l.fields) == 0 {
-		return fmt.Sprintf("%s %s", timestamp, message)
-	}
+// EnableStackTrace turns on a captured goroutine stack trace attached to
+// every ERROR record's "stack" field, in addition to whatever
+// EnableCallerInfo provides.
+func (l *Logger) EnableStackTrace(enabled bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stackTraceEnabled = enabled
+}
 
-	data := map[string]interface{}{
-		"timestamp": timestamp,
-		"level":     level,
-		"message":   message,
+// WithFields adds structured fields to the log output
+func (l *Logger) WithFields(fields map[string]interface{}) *Logger {
+	l.mu.Lock()
+	sinks := l.sinks
+	minLevel := l.minLevel
+	base := l.fields
+	async := l.async
+	vmodule := l.vmodule
+	callerEnabled := l.callerEnabled
+	callerDepth := l.callerDepth
+	stackTraceEnabled := l.stackTraceEnabled
+	l.mu.Unlock()
+
+	merged := make(map[string]interface{}, len(base)+len(fields))
+	for k, v := range base {
+		merged[k] = v
 	}
-	for k, v := range l.fields {
-		data[k] = v
+	for k, v := range fields {
+		merged[k] = v
 	}
 
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return fmt.Sprintf("%s %s", timestamp, message)
+	clone := &Logger{
+		sinks:             sinks,
+		minLevel:          minLevel,
+		async:             async,
+		fields:            merged,
+		vmodule:           vmodule,
+		callerEnabled:     callerEnabled,
+		callerDepth:       callerDepth,
+		stackTraceEnabled: stackTraceEnabled,
 	}
-	return string(jsonData)
+	atomic.StoreInt32(&clone.verbosity, atomic.LoadInt32(&l.verbosity))
+	return clone
 }
 
-// checkRotation checks if log rotation is needed and performs rotation if necessary
-func (l *Logger) checkRotation(messageSize int) {
+// Flush drains any queued messages through the sinks and flushes every
+// registered sink. It is a no-op beyond flushing sinks for synchronous
+// loggers, since they have nothing queued.
+func (l *Logger) Flush() {
 	l.mu.Lock()
-	defer l.mu.Unlock()
+	sinks := l.sinks
+	async := l.async
+	l.mu.Unlock()
 
-	l.currentSize += int64(messageSize)
-	if l.currentSize >= l.maxFileSize {
-		l.rotate()
+	if async != nil {
+		async.drainSync()
 	}
-}
 
-// rotate performs log file rotation
-func (l *Logger) rotate() {
-	// Close current file
-	l.file.Close()
-
-	// Rotate backup files
-	for i := l.maxBackups - 1; i > 0; i-- {
-		oldPath := fmt.Sprintf("%s.%d", l.filePath, i)
-		newPath := fmt.Sprintf("%s.%d", l.filePath, i+1)
-		os.Rename(oldPath, newPath)
+	for _, entry := range sinks {
+		entry.sink.Flush()
 	}
+}
 
-	// Rename current log file
-	os.Rename(l.filePath, l.filePath+".1")
+// Close drains and stops any async drain goroutine, then closes every
+// registered sink.
+func (l *Logger) Close() {
+	l.mu.Lock()
+	sinks := l.sinks
+	async := l.async
+	l.mu.Unlock()
 
-	// Create new log file
-	file, err := os.OpenFile(l.filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
-	if err == nil {
-		l.file = file
-		l.warning.SetOutput(file)
-		l.error.SetOutput(file)
-		l.currentSize = 0
+	if async != nil {
+		async.stop()
 	}
-}
 
-// Close closes the log file
-func (l *Logger) Close() {
-	if l.file != nil {
-		l.file.Close()
+	for _, entry := range sinks {
+		entry.sink.Close()
 	}
 }
@@ -3,10 +3,13 @@ package golog
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestNewLogger(t *testing.T) {
@@ -120,14 +123,14 @@ func TestLogRotation(t *testing.T) {
 	defer os.RemoveAll(tmpDir)
 
 	logFile := filepath.Join(tmpDir, "test.log")
-	logger, err := NewLogger(logFile)
+	sink, err := NewFileSink(FileSinkConfig{Path: logFile, MaxFileSize: 100})
 	if err != nil {
-		t.Fatalf("Failed to create logger: %v", err)
+		t.Fatalf("Failed to create file sink: %v", err)
 	}
-	defer logger.Close()
 
-	// Set a small max file size for testing
-	logger.maxFileSize = 100
+	logger := &Logger{}
+	logger.AddSink(sink, WARNING)
+	defer logger.Close()
 
 	// Write enough data to trigger rotation
 	for i := 0; i < 10; i++ {
@@ -141,6 +144,111 @@ func TestLogRotation(t *testing.T) {
 	}
 }
 
+func TestLogRotationWithCompression(t *testing.T) {
+	// Create a temporary directory for test files
+	tmpDir, err := os.MkdirTemp("", "logger_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	logFile := filepath.Join(tmpDir, "test.log")
+	sink, err := NewFileSink(FileSinkConfig{Path: logFile, MaxFileSize: 100, Compress: true})
+	if err != nil {
+		t.Fatalf("Failed to create file sink: %v", err)
+	}
+
+	logger := &Logger{}
+	logger.AddSink(sink, WARNING)
+
+	// Write enough data to trigger multiple rotations
+	for i := 0; i < 20; i++ {
+		logger.Warning(fmt.Sprintf("test message %d with some padding to increase size", i))
+	}
+
+	// Wait for background compression to finish
+	logger.Close()
+
+	// The newest backup should stay uncompressed
+	if _, err := os.Stat(logFile + ".1"); os.IsNotExist(err) {
+		t.Error("Expected rotation file .1 to exist")
+	}
+
+	// Older backups should be gzip-compressed
+	gzPath := logFile + ".2.gz"
+	if _, err := os.Stat(gzPath); os.IsNotExist(err) {
+		t.Fatal("Expected compressed rotation file .2.gz to exist")
+	}
+
+	reader, err := OpenLogBackup(gzPath)
+	if err != nil {
+		t.Fatalf("OpenLogBackup() error = %v", err)
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Failed to read compressed backup: %v", err)
+	}
+	if !strings.Contains(string(content), "test message") {
+		t.Error("Expected decompressed backup to contain log messages")
+	}
+}
+
+func TestFileSinkCloseRaceDuringCompression(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "logger_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	logFile := filepath.Join(tmpDir, "test.log")
+	sink, err := NewFileSink(FileSinkConfig{Path: logFile, MaxFileSize: 40, Compress: true})
+	if err != nil {
+		t.Fatalf("Failed to create file sink: %v", err)
+	}
+
+	logger := &Logger{}
+	logger.AddSink(sink, WARNING)
+
+	// Hammer rotations (and therefore the compression worker) on one
+	// goroutine while Close races in on another; this used to panic with
+	// "send on closed channel" when a rotation was mid-flight inside
+	// submitCompressJob as Close closed compressJobs.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 500; i++ {
+			logger.Warning("padding out this message to force rotation %d", i)
+		}
+	}()
+
+	logger.Close()
+	<-done
+}
+
+func TestFileSinkDoubleCloseIsNoop(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "logger_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	logFile := filepath.Join(tmpDir, "test.log")
+	sink, err := NewFileSink(FileSinkConfig{Path: logFile, Compress: true})
+	if err != nil {
+		t.Fatalf("Failed to create file sink: %v", err)
+	}
+
+	logger := &Logger{}
+	logger.AddSink(sink, WARNING)
+
+	// A second Close (e.g. a deferred Close landing after an explicit one on
+	// an error path) used to panic with "close of closed channel".
+	logger.Close()
+	logger.Close()
+}
+
 func TestWithFields(t *testing.T) {
 	// Create a temporary directory for test files
 	tmpDir, err := os.MkdirTemp("", "logger_test")
@@ -179,3 +287,337 @@ func TestWithFields(t *testing.T) {
 		t.Error("action field not found in structured output")
 	}
 }
+
+// recordingSink is a LogSink test double that captures every emitted record.
+type recordingSink struct {
+	mu         sync.Mutex
+	records    []string
+	lastFields map[string]interface{}
+	closed     bool
+}
+
+func (r *recordingSink) Emit(level LogLevel, ts time.Time, msg string, fields map[string]interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = append(r.records, fmt.Sprintf("%s:%s", levelName(level), msg))
+	r.lastFields = fields
+	return nil
+}
+
+func (r *recordingSink) lastFieldsSnapshot() map[string]interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lastFields
+}
+
+func (r *recordingSink) Flush() error { return nil }
+
+func (r *recordingSink) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	return nil
+}
+
+func (r *recordingSink) snapshot() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.records...)
+}
+
+func TestAddSinkFanOut(t *testing.T) {
+	logger := &Logger{}
+
+	everything := &recordingSink{}
+	warningsOnly := &recordingSink{}
+	logger.AddSink(everything, DEBUG)
+	logger.AddSink(warningsOnly, WARNING)
+
+	logger.Debug("debug message")
+	logger.Warning("warning message")
+
+	if got := everything.snapshot(); len(got) != 2 {
+		t.Errorf("Expected sink registered at DEBUG to receive both records, got %v", got)
+	}
+	if got := warningsOnly.snapshot(); len(got) != 1 || got[0] != "WARNING:warning message" {
+		t.Errorf("Expected sink registered at WARNING to receive only the warning, got %v", got)
+	}
+
+	logger.Close()
+	if !everything.closed || !warningsOnly.closed {
+		t.Error("Expected Logger.Close() to close every registered sink")
+	}
+}
+
+func TestNewAsyncLogger(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "logger_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	logFile := filepath.Join(tmpDir, "test.log")
+	logger, err := NewAsyncLogger(logFile, 4)
+	if err != nil {
+		t.Fatalf("Failed to create async logger: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		logger.Warning("async message %d", i)
+	}
+
+	// Flush should block until every enqueued message has reached the sinks.
+	logger.Flush()
+
+	content, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		if !strings.Contains(string(content), fmt.Sprintf("async message %d", i)) {
+			t.Errorf("Expected flushed log file to contain message %d", i)
+		}
+	}
+
+	logger.Close()
+}
+
+func TestAsyncLoggerDropPolicy(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "logger_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	logFile := filepath.Join(tmpDir, "test.log")
+	logger, err := NewAsyncLogger(logFile, 1)
+	if err != nil {
+		t.Fatalf("Failed to create async logger: %v", err)
+	}
+	defer logger.Close()
+
+	logger.SetDropPolicy(DropPolicyDropNewest)
+
+	// Flood the tiny queue faster than the drain goroutine can keep up so
+	// most sends are dropped, then confirm Stats() reports them accurately:
+	// under DropPolicyDropNewest every message either lands in the file or
+	// is counted as dropped, so the two must add up to the total sent.
+	const total = 5000
+	for i := 0; i < total; i++ {
+		logger.Warning("flood message %d", i)
+	}
+
+	logger.Flush()
+
+	dropped := logger.Stats().Dropped
+	if dropped == 0 {
+		t.Fatal("expected some messages to be dropped, got 0")
+	}
+	if dropped > total {
+		t.Fatalf("Dropped count %d exceeds messages sent", dropped)
+	}
+
+	content, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	written := uint64(strings.Count(string(content), "flood message"))
+	if written+dropped != total {
+		t.Errorf("written (%d) + dropped (%d) = %d, want %d", written, dropped, written+dropped, total)
+	}
+}
+
+func TestAsyncLoggerDropOldestCountsPops(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "logger_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	logFile := filepath.Join(tmpDir, "test.log")
+	logger, err := NewAsyncLogger(logFile, 1)
+	if err != nil {
+		t.Fatalf("Failed to create async logger: %v", err)
+	}
+	defer logger.Close()
+
+	logger.SetDropPolicy(DropPolicyDropOldest)
+
+	// Flood a size-1 queue so the drain goroutine can't keep up; under
+	// DropPolicyDropOldest, each successful pop-to-make-room is a real drop
+	// and must be counted even though the subsequent push usually succeeds.
+	for i := 0; i < 5000; i++ {
+		logger.Warning("flood message %d", i)
+	}
+
+	logger.Flush()
+	if logger.Stats().Dropped == 0 {
+		t.Fatal("expected DropPolicyDropOldest to report dropped messages, got 0")
+	}
+}
+
+func TestRotateByLines(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "logger_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	logFile := filepath.Join(tmpDir, "test.log")
+	sink, err := NewFileSink(FileSinkConfig{Path: logFile, RotationPolicy: RotateByLines, MaxLines: 3})
+	if err != nil {
+		t.Fatalf("Failed to create file sink: %v", err)
+	}
+
+	logger := &Logger{}
+	logger.AddSink(sink, WARNING)
+	defer logger.Close()
+
+	for i := 0; i < 7; i++ {
+		logger.Warning("line %d", i)
+	}
+
+	if _, err := os.Stat(logFile + ".1"); os.IsNotExist(err) {
+		t.Error("Expected rotation file .1 to exist after exceeding MaxLines")
+	}
+}
+
+func TestRotateDaily(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "logger_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	logFile := filepath.Join(tmpDir, "test.log")
+	sink, err := NewFileSink(FileSinkConfig{Path: logFile, RotationPolicy: RotateDaily})
+	if err != nil {
+		t.Fatalf("Failed to create file sink: %v", err)
+	}
+
+	logger := &Logger{}
+	logger.AddSink(sink, WARNING)
+	defer logger.Close()
+
+	logger.Warning("before boundary")
+
+	// Force the next Emit to see a boundary crossing without waiting for a
+	// real midnight.
+	fs := sink.(*fileSink)
+	fs.mu.Lock()
+	fs.periodEnd = time.Now()
+	fs.mu.Unlock()
+
+	logger.Warning("after boundary")
+
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to read temp dir: %v", err)
+	}
+	dateSuffixed := false
+	for _, e := range entries {
+		if e.Name() != "test.log" && strings.HasPrefix(e.Name(), "test.log.") && !strings.HasSuffix(e.Name(), ".gz") {
+			dateSuffixed = true
+		}
+	}
+	if !dateSuffixed {
+		t.Errorf("Expected a date-suffixed backup file, got entries: %v", entries)
+	}
+}
+
+func TestVLoggerGatedByVerbosity(t *testing.T) {
+	logger := &Logger{}
+	sink := &recordingSink{}
+	logger.AddSink(sink, DEBUG)
+
+	logger.SetVerbosity(1)
+
+	if !logger.V(1).Enabled() {
+		t.Error("Expected V(1) to be enabled at verbosity 1")
+	}
+	if logger.V(2).Enabled() {
+		t.Error("Expected V(2) to be disabled at verbosity 1")
+	}
+
+	logger.V(1).Infof("shown")
+	logger.V(2).Infof("hidden")
+
+	if got := sink.snapshot(); len(got) != 1 || got[0] != "INFO:shown" {
+		t.Errorf("Expected only the V(1) message to be logged, got %v", got)
+	}
+}
+
+func TestVModuleOverridesVerbosity(t *testing.T) {
+	logger := &Logger{}
+	sink := &recordingSink{}
+	logger.AddSink(sink, DEBUG)
+
+	logger.SetVerbosity(0)
+	logger.SetVModule("logger_test.go=3,net/*=2")
+
+	if !logger.V(3).Enabled() {
+		t.Error("Expected vmodule to raise the verbosity for this file to 3")
+	}
+
+	// Resolution is cached; changing SetVModule again must invalidate it.
+	logger.SetVModule("logger_test.go=0")
+	if logger.V(3).Enabled() {
+		t.Error("Expected SetVModule to invalidate the cached resolution")
+	}
+}
+
+func TestEnableCallerInfo(t *testing.T) {
+	logger := &Logger{}
+	sink := &recordingSink{}
+	logger.AddSink(sink, DEBUG)
+	logger.EnableCallerInfo(0)
+
+	logger.Info("hello")
+
+	fields := sink.lastFieldsSnapshot()
+	file, _ := fields["file"].(string)
+	if !strings.HasSuffix(file, "logger_test.go") {
+		t.Errorf("Expected file field to point at this test file, got %q", file)
+	}
+	if _, ok := fields["line"].(int); !ok {
+		t.Errorf("Expected an int line field, got %v", fields["line"])
+	}
+	if _, ok := fields["func"]; !ok {
+		t.Error("Expected a func field to be resolved")
+	}
+}
+
+func TestEnableStackTraceOnlyOnError(t *testing.T) {
+	logger := &Logger{}
+	sink := &recordingSink{}
+	logger.AddSink(sink, DEBUG)
+	logger.EnableStackTrace(true)
+
+	logger.Warning("no stack expected")
+	if _, ok := sink.lastFieldsSnapshot()["stack"]; ok {
+		t.Error("Expected no stack field on a WARNING record")
+	}
+
+	logger.Error("stack expected")
+	stack, ok := sink.lastFieldsSnapshot()["stack"].(string)
+	if !ok || !strings.Contains(stack, "goroutine") {
+		t.Errorf("Expected a captured goroutine stack on the ERROR record, got %v", sink.lastFieldsSnapshot()["stack"])
+	}
+}
+
+func TestWithFieldsPreservesCallerInfo(t *testing.T) {
+	logger := &Logger{}
+	sink := &recordingSink{}
+	logger.AddSink(sink, DEBUG)
+	logger.EnableCallerInfo(0)
+
+	logger.WithFields(map[string]interface{}{"request_id": "abc"}).Info("hello")
+
+	fields := sink.lastFieldsSnapshot()
+	if _, ok := fields["file"]; !ok {
+		t.Error("Expected caller info to flow through WithFields")
+	}
+	if fields["request_id"] != "abc" {
+		t.Errorf("Expected structured fields to survive alongside caller info, got %v", fields)
+	}
+}
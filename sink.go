@@ -0,0 +1,528 @@
+package golog
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LogSink is a destination a Logger fans records out to. Built-in sinks
+// cover stdout/stderr, a rotating file, and arbitrary io.Writers;
+// callers can implement their own (syslog, HTTP, Kafka, ...) and register it
+// with Logger.AddSink.
+type LogSink interface {
+	// Emit writes a single log record to the sink.
+	Emit(level LogLevel, ts time.Time, msg string, fields map[string]interface{}) error
+	// Flush ensures any buffered output has been written out.
+	Flush() error
+	// Close releases any resources held by the sink.
+	Close() error
+}
+
+// levelName returns the text label used in formatted output for level.
+func levelName(level LogLevel) string {
+	switch level {
+	case DEBUG:
+		return "DEBUG"
+	case INFO:
+		return "INFO"
+	case WARNING:
+		return "WARNING"
+	case ERROR:
+		return "ERROR"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// formatRecord renders a record as JSON when fields are present, or as a
+// plain "timestamp message" line otherwise.
+func formatRecord(levelLabel string, ts time.Time, msg string, fields map[string]interface{}) string {
+	timestamp := ts.Format("2006-01-02 15:04:05")
+
+	if len(fields) == 0 {
+		return fmt.Sprintf("%s %s", timestamp, msg)
+	}
+
+	data := map[string]interface{}{
+		"timestamp": timestamp,
+		"level":     levelLabel,
+		"message":   msg,
+	}
+	for k, v := range fields {
+		data[k] = v
+	}
+
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Sprintf("%s %s", timestamp, msg)
+	}
+	return string(jsonData)
+}
+
+// writerSink is a LogSink that writes formatted records to an io.Writer.
+type writerSink struct {
+	mu     sync.Mutex
+	logger *log.Logger
+}
+
+// NewWriterSink returns a LogSink that writes human-readable records to w.
+func NewWriterSink(w io.Writer) LogSink {
+	return &writerSink{logger: log.New(w, "", log.Ldate|log.Ltime)}
+}
+
+// NewStdoutSink returns a LogSink that writes to os.Stdout.
+func NewStdoutSink() LogSink { return NewWriterSink(os.Stdout) }
+
+// NewStderrSink returns a LogSink that writes to os.Stderr.
+func NewStderrSink() LogSink { return NewWriterSink(os.Stderr) }
+
+func (w *writerSink) Emit(level LogLevel, ts time.Time, msg string, fields map[string]interface{}) error {
+	label := levelName(level)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.logger.Print(label + ": " + formatRecord(label, ts, msg, fields))
+	return nil
+}
+
+func (w *writerSink) Flush() error { return nil }
+func (w *writerSink) Close() error { return nil }
+
+// legacyConsoleSink reproduces NewLogger's original console routing, where
+// only DEBUG and INFO records reached stdout.
+type legacyConsoleSink struct {
+	inner LogSink
+}
+
+func (c *legacyConsoleSink) Emit(level LogLevel, ts time.Time, msg string, fields map[string]interface{}) error {
+	if level > INFO {
+		return nil
+	}
+	return c.inner.Emit(level, ts, msg, fields)
+}
+
+func (c *legacyConsoleSink) Flush() error { return c.inner.Flush() }
+func (c *legacyConsoleSink) Close() error { return c.inner.Close() }
+
+// RotationPolicy selects which triggers cause a fileSink to rotate. Values
+// may be OR'd together (e.g. RotateBySize|RotateDaily) so rotation happens
+// on whichever trigger fires first.
+type RotationPolicy int
+
+const (
+	// RotateBySize rotates once the file grows past MaxFileSize.
+	RotateBySize RotationPolicy = 1 << iota
+	// RotateByLines rotates once MaxLines records have been written.
+	RotateByLines
+	// RotateDaily rotates at the next local-midnight boundary.
+	RotateDaily
+	// RotateHourly rotates at the next local top-of-hour boundary.
+	RotateHourly
+)
+
+// FileSinkConfig configures a rotating file sink created by NewFileSink.
+type FileSinkConfig struct {
+	// Path is the log file to write to.
+	Path string
+	// MaxFileSize is the size, in bytes, at which RotateBySize triggers.
+	// Defaults to defaultMaxSize when zero.
+	MaxFileSize int64
+	// MaxLines is the number of records at which RotateByLines triggers.
+	// Defaults to defaultMaxLines when zero.
+	MaxLines int
+	// MaxBackups is the number of numbered (size/line) backups to keep.
+	// Defaults to defaultMaxBackups when zero. Time-based backups are kept
+	// indefinitely, one per period, since they're named by date rather than
+	// numbered.
+	MaxBackups int
+	// RotationPolicy selects which triggers are active. Defaults to
+	// RotateBySize when zero.
+	RotationPolicy RotationPolicy
+	// Compress gzips rotated numbered backups older than the newest one
+	// (the newest backup, path.1, always stays plain). Time-based backups
+	// are never compressed.
+	Compress bool
+}
+
+// fileSink is a LogSink that writes to a rotated file, optionally
+// gzip-compressing older numbered backups off the hot path.
+type fileSink struct {
+	mu             sync.Mutex
+	logger         *log.Logger
+	file           *os.File
+	path           string
+	maxFileSize    int64
+	maxLines       int
+	maxBackups     int
+	rotationPolicy RotationPolicy
+	compress       bool
+	currentSize    int64
+	lineCount      int
+	pendingSeq     int
+	periodEnd      time.Time // next RotateDaily/RotateHourly boundary; zero if neither is active
+
+	// compressJobs feeds a single dedicated worker goroutine so rotations
+	// that outrun compression queue up and complete in order, instead of
+	// racing each other for the same numbered .gz slot.
+	compressJobs    chan compressJob
+	compressWg      sync.WaitGroup
+	compressDropped atomic.Uint64
+
+	// closeMu guards against sending on compressJobs after it has been
+	// closed: submitCompressJob holds it for reading while it sends, and
+	// Close takes it for writing before closing the channel, so a send can
+	// never race a close (mirrors asyncState.closeMu).
+	closeMu sync.RWMutex
+	closed  bool
+}
+
+// rotateReason records which trigger caused a rotation, since that decides
+// how the resulting backup is named.
+type rotateReason int
+
+const (
+	rotateReasonSize rotateReason = iota
+	rotateReasonTime
+)
+
+// compressJob asks the compression worker to shift the existing .gz backups
+// up one slot and compress pendingSrc into the freed path.2.gz.
+type compressJob struct {
+	pendingSrc string
+}
+
+// NewFileSink opens (or creates) the file at cfg.Path and returns a LogSink
+// that rotates it on the triggers selected by cfg.RotationPolicy (by default,
+// once it grows past cfg.MaxFileSize).
+func NewFileSink(cfg FileSinkConfig) (LogSink, error) {
+	file, err := os.OpenFile(cfg.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	maxFileSize := cfg.MaxFileSize
+	if maxFileSize == 0 {
+		maxFileSize = defaultMaxSize
+	}
+	maxLines := cfg.MaxLines
+	if maxLines == 0 {
+		maxLines = defaultMaxLines
+	}
+	maxBackups := cfg.MaxBackups
+	if maxBackups == 0 {
+		maxBackups = defaultMaxBackups
+	}
+	rotationPolicy := cfg.RotationPolicy
+	if rotationPolicy == 0 {
+		rotationPolicy = RotateBySize
+	}
+
+	f := &fileSink{
+		logger:         log.New(file, "", log.Ldate|log.Ltime),
+		file:           file,
+		path:           cfg.Path,
+		maxFileSize:    maxFileSize,
+		maxLines:       maxLines,
+		maxBackups:     maxBackups,
+		rotationPolicy: rotationPolicy,
+		compress:       cfg.Compress,
+		periodEnd:      nextRotationBoundary(rotationPolicy),
+	}
+
+	if f.compress {
+		f.compressJobs = make(chan compressJob, maxBackups)
+		f.compressWg.Add(1)
+		go f.compressWorker()
+	}
+
+	return f, nil
+}
+
+// nextRotationBoundary returns the next local RotateDaily/RotateHourly boundary for
+// policy, or the zero time if neither is active.
+func nextRotationBoundary(policy RotationPolicy) time.Time {
+	now := time.Now()
+	switch {
+	case policy&RotateHourly != 0:
+		return now.Truncate(time.Hour).Add(time.Hour)
+	case policy&RotateDaily != 0:
+		year, month, day := now.Date()
+		midnight := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+		return midnight.AddDate(0, 0, 1)
+	default:
+		return time.Time{}
+	}
+}
+
+func (f *fileSink) Emit(level LogLevel, ts time.Time, msg string, fields map[string]interface{}) error {
+	label := levelName(level)
+	line := label + ": " + formatRecord(label, ts, msg, fields)
+
+	f.mu.Lock()
+	f.logger.Print(line)
+	f.currentSize += int64(len(line))
+	f.lineCount++
+
+	var job *compressJob
+	if reason, trigger := f.rotationTrigger(ts); trigger {
+		job = f.rotate(reason)
+	}
+	f.mu.Unlock()
+
+	// Handing the job to the compression worker happens after f.mu is
+	// released: the worker's channel can back up under bursty rotations,
+	// and queuing must never stall the Warning/Error call that triggered it.
+	if job != nil {
+		f.submitCompressJob(*job)
+	}
+	return nil
+}
+
+// rotationTrigger reports whether a configured policy has tripped and, if
+// so, which reason should decide the backup's naming scheme. Callers must
+// hold f.mu.
+func (f *fileSink) rotationTrigger(now time.Time) (rotateReason, bool) {
+	if !f.periodEnd.IsZero() && !now.Before(f.periodEnd) {
+		return rotateReasonTime, true
+	}
+	if f.rotationPolicy&RotateBySize != 0 && f.currentSize >= f.maxFileSize {
+		return rotateReasonSize, true
+	}
+	if f.rotationPolicy&RotateByLines != 0 && f.lineCount >= f.maxLines {
+		return rotateReasonSize, true
+	}
+	return rotateReasonSize, false
+}
+
+func (f *fileSink) Flush() error { return nil }
+
+// Close stops the compression worker (if any) and closes the underlying
+// file. It is safe to call concurrently with in-flight Emit calls: taking
+// closeMu for writing before closing compressJobs can't happen until any
+// submitCompressJob send already in flight has released its read lock, so a
+// rotation racing Close can never send on (or panic on) a closed channel.
+// Calling Close more than once is a no-op past the first call, mirroring
+// asyncState.stop, so a defensive double-Close (e.g. a deferred Close plus
+// an explicit one on an error path) never panics on an already-closed
+// compressJobs.
+func (f *fileSink) Close() error {
+	f.closeMu.Lock()
+	if f.closed {
+		f.closeMu.Unlock()
+		return nil
+	}
+	f.closed = true
+	if f.compressJobs != nil {
+		close(f.compressJobs)
+	}
+	f.closeMu.Unlock()
+	f.compressWg.Wait()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Close()
+}
+
+// rotate performs log file rotation and returns the compression job it
+// produced, if any, for the caller to hand to the worker once f.mu is
+// released. Callers must hold f.mu.
+func (f *fileSink) rotate(reason rotateReason) *compressJob {
+	f.file.Close()
+
+	var job *compressJob
+	switch {
+	case reason == rotateReasonTime:
+		f.rotateByDate()
+	case f.compress:
+		job = f.rotateWithCompression()
+	default:
+		f.rotatePlain()
+	}
+
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+	if err == nil {
+		f.file = file
+		f.logger.SetOutput(file)
+		f.currentSize = 0
+		f.lineCount = 0
+		f.periodEnd = nextRotationBoundary(f.rotationPolicy)
+	}
+	return job
+}
+
+// rotateByDate renames the current file with a date (or date-hour) suffix
+// naming the period that just elapsed, e.g. app.log.2024-05-01, instead of
+// the numeric .1/.2 scheme used by size/line rotation.
+func (f *fileSink) rotateByDate() {
+	layout := "2006-01-02"
+	if f.rotationPolicy&RotateHourly != 0 {
+		layout = "2006-01-02-15"
+	}
+
+	// periodEnd is the boundary that was just crossed, so the period it
+	// closes out is one tick before it.
+	suffix := f.periodEnd.Add(-time.Nanosecond).Format(layout)
+	backup := uniqueBackupPath(fmt.Sprintf("%s.%s", f.path, suffix))
+	os.Rename(f.path, backup)
+}
+
+// uniqueBackupPath returns base, or base with a numeric suffix appended if
+// base already exists (e.g. the process restarted mid-period).
+func uniqueBackupPath(base string) string {
+	if _, err := os.Stat(base); os.IsNotExist(err) {
+		return base
+	}
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s.%d", base, i)
+		if _, err := os.Stat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
+	}
+}
+
+// rotatePlain shifts numbered backup files (path.1..N) without compression.
+func (f *fileSink) rotatePlain() {
+	for i := f.maxBackups - 1; i > 0; i-- {
+		oldPath := fmt.Sprintf("%s.%d", f.path, i)
+		newPath := fmt.Sprintf("%s.%d", f.path, i+1)
+		os.Rename(oldPath, newPath)
+	}
+
+	os.Rename(f.path, f.path+".1")
+}
+
+// rotateWithCompression moves the previous path.1 aside and returns a job
+// for the compression worker, which shifts older .gz backups up a slot and
+// compresses it into path.2.gz so only the newest backup stays plain. The
+// shift-then-compress sequence runs entirely on the worker so back-to-back
+// rotations can't race each other for the same .gz slot.
+func (f *fileSink) rotateWithCompression() *compressJob {
+	backup1 := f.path + ".1"
+	var job *compressJob
+	if f.maxBackups >= 2 {
+		if _, err := os.Stat(backup1); err == nil {
+			f.pendingSeq++
+			pending := fmt.Sprintf("%s.pending-%d", backup1, f.pendingSeq)
+			if err := os.Rename(backup1, pending); err == nil {
+				job = &compressJob{pendingSrc: pending}
+			}
+		}
+	}
+
+	os.Rename(f.path, backup1)
+	return job
+}
+
+// submitCompressJob hands job to the compression worker without blocking the
+// caller: it uses a non-blocking send guarded by closeMu, mirroring
+// asyncState.enqueue, so a send can never race Close closing the channel and
+// a saturated worker (compressJobs is buffered to maxBackups) never stalls a
+// Warning/Error call. A job that can't be queued is dropped and counted in
+// compressDropped; the rename onto path.1 has already happened, so at worst
+// one intermediate backup stays uncompressed.
+func (f *fileSink) submitCompressJob(job compressJob) {
+	f.closeMu.RLock()
+	defer f.closeMu.RUnlock()
+	if f.closed {
+		return
+	}
+
+	select {
+	case f.compressJobs <- job:
+	default:
+		f.compressDropped.Add(1)
+	}
+}
+
+// compressWorker serially processes compression jobs so concurrent rotations
+// never write the same .gz backup at once.
+func (f *fileSink) compressWorker() {
+	defer f.compressWg.Done()
+	for job := range f.compressJobs {
+		f.runCompressJob(job)
+	}
+}
+
+func (f *fileSink) runCompressJob(job compressJob) {
+	for i := f.maxBackups - 1; i > 1; i-- {
+		oldPath := fmt.Sprintf("%s.%d.gz", f.path, i)
+		newPath := fmt.Sprintf("%s.%d.gz", f.path, i+1)
+		os.Rename(oldPath, newPath)
+	}
+
+	dst := fmt.Sprintf("%s.2.gz", f.path)
+	if err := gzipFile(job.pendingSrc, dst); err != nil {
+		return
+	}
+	os.Remove(job.pendingSrc)
+}
+
+// gzipFile writes a gzip-compressed copy of srcPath to dstPath.
+func gzipFile(srcPath, dstPath string) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(dstPath)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	gw := gzip.NewWriter(dst)
+	if _, err := io.Copy(gw, src); err != nil {
+		gw.Close()
+		return err
+	}
+	return gw.Close()
+}
+
+// OpenLogBackup opens a rotated backup file, transparently decompressing it
+// if it was written with Compress enabled, so tooling can iterate historical
+// logs without knowing the storage form.
+func OpenLogBackup(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log backup: %w", err)
+	}
+
+	if !strings.HasSuffix(path, ".gz") {
+		return file, nil
+	}
+
+	gr, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to read gzipped log backup: %w", err)
+	}
+	return &gzipBackupReader{gzipReader: gr, file: file}, nil
+}
+
+// gzipBackupReader closes both the gzip reader and its underlying file.
+type gzipBackupReader struct {
+	gzipReader *gzip.Reader
+	file       *os.File
+}
+
+func (g *gzipBackupReader) Read(p []byte) (int, error) {
+	return g.gzipReader.Read(p)
+}
+
+func (g *gzipBackupReader) Close() error {
+	gzErr := g.gzipReader.Close()
+	fileErr := g.file.Close()
+	if gzErr != nil {
+		return gzErr
+	}
+	return fileErr
+}
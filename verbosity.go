@@ -0,0 +1,153 @@
+package golog
+
+import (
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// VerboseLogger gates INFO-level output behind a verbosity threshold,
+// mirroring glog/klog's V(level).Infof(...) pattern.
+type VerboseLogger struct {
+	logger  *Logger
+	enabled bool
+}
+
+// vmoduleRule maps a -vmodule-style file pattern to the verbosity level that
+// applies to matching source files.
+type vmoduleRule struct {
+	pattern string
+	level   int
+}
+
+// V returns a VerboseLogger for level, resolved against the global
+// verbosity and any SetVModule pattern matching the caller's source file.
+func (l *Logger) V(level int) VerboseLogger {
+	file := ""
+	if _, callerFile, _, ok := runtime.Caller(1); ok {
+		file = callerFile
+	}
+	return VerboseLogger{logger: l, enabled: l.verbosityEnabled(level, file)}
+}
+
+// Enabled reports whether this verbosity level would actually emit, so
+// callers can skip building expensive log arguments.
+func (v VerboseLogger) Enabled() bool {
+	return v.enabled
+}
+
+// Infof logs at INFO level if this verbosity level is enabled.
+func (v VerboseLogger) Infof(format string, args ...interface{}) {
+	if !v.enabled {
+		return
+	}
+	v.logger.emit(INFO, format, args...)
+}
+
+// SetVerbosity sets the global verbosity threshold used by V() when no
+// SetVModule pattern matches the caller's file.
+func (l *Logger) SetVerbosity(level int) {
+	atomic.StoreInt32(&l.verbosity, int32(level))
+}
+
+// SetVModule configures per-file verbosity overrides using glog's
+// "pattern=level,pattern=level" syntax, e.g. "net/*=2,cache.go=3". A pattern
+// without a "/" matches against the file's base name; a pattern with "/"
+// matches against the file's trailing path components. The first matching
+// pattern wins, so list more specific patterns first.
+func (l *Logger) SetVModule(spec string) {
+	rules := parseVModule(spec)
+
+	l.mu.Lock()
+	l.vmodule = rules
+	l.mu.Unlock()
+
+	l.vmoduleCache.Range(func(key, _ interface{}) bool {
+		l.vmoduleCache.Delete(key)
+		return true
+	})
+}
+
+// parseVModule parses a -vmodule-style spec into rules, skipping malformed entries.
+func parseVModule(spec string) []vmoduleRule {
+	var rules []vmoduleRule
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		pattern, levelStr, found := strings.Cut(part, "=")
+		if !found {
+			continue
+		}
+
+		level, err := strconv.Atoi(strings.TrimSpace(levelStr))
+		if err != nil {
+			continue
+		}
+		rules = append(rules, vmoduleRule{pattern: strings.TrimSpace(pattern), level: level})
+	}
+	return rules
+}
+
+// verbosityEnabled resolves the effective threshold for file (caching it
+// when a SetVModule pattern matches; otherwise the global verbosity applies
+// and nothing is cached) and reports whether level is at or below it.
+func (l *Logger) verbosityEnabled(level int, file string) bool {
+	threshold := int(atomic.LoadInt32(&l.verbosity))
+
+	if file != "" {
+		if cached, ok := l.vmoduleCache.Load(file); ok {
+			threshold = cached.(int)
+		} else if resolved, ok := l.resolveVModule(file); ok {
+			l.vmoduleCache.Store(file, resolved)
+			threshold = resolved
+		}
+	}
+
+	return level <= threshold
+}
+
+// resolveVModule finds the first configured pattern matching file.
+func (l *Logger) resolveVModule(file string) (int, bool) {
+	l.mu.Lock()
+	rules := l.vmodule
+	l.mu.Unlock()
+
+	for _, rule := range rules {
+		if vmoduleMatches(rule.pattern, file) {
+			return rule.level, true
+		}
+	}
+	return 0, false
+}
+
+// vmoduleMatches reports whether pattern matches file. Patterns without a
+// "/" are matched against file's base name; patterns with a "/" are matched
+// component-by-component against file's trailing path segments.
+func vmoduleMatches(pattern, file string) bool {
+	pattern = filepath.ToSlash(pattern)
+	file = filepath.ToSlash(file)
+
+	if !strings.Contains(pattern, "/") {
+		matched, _ := filepath.Match(pattern, filepath.Base(file))
+		return matched
+	}
+
+	patternParts := strings.Split(pattern, "/")
+	fileParts := strings.Split(file, "/")
+	if len(patternParts) > len(fileParts) {
+		return false
+	}
+
+	tail := fileParts[len(fileParts)-len(patternParts):]
+	for i, p := range patternParts {
+		if matched, _ := filepath.Match(p, tail[i]); !matched {
+			return false
+		}
+	}
+	return true
+}